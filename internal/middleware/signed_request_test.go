@@ -0,0 +1,172 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/VanDuc0209/gin-clean-template/pkg/cache"
+)
+
+const testSecret = "partner-secret"
+
+func newSignedRouter(t *testing.T, nonces cache.CounterStore) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.POST("/hook", SignedRequest(SignedRequestConfig{Secret: testSecret, Nonces: nonces, MaxSkew: time.Minute}),
+		func(c *gin.Context) {
+			body, _ := io.ReadAll(c.Request.Body)
+			c.String(http.StatusOK, string(body))
+		})
+	return r
+}
+
+func newMemoryNonces(t *testing.T) cache.CounterStore {
+	t.Helper()
+	s := cache.NewExpiringCounterStore(time.Minute)
+	t.Cleanup(s.Stop)
+	return s
+}
+
+func signedRequest(body string, ts int64, nonce, signature string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/hook", strings.NewReader(body))
+	req.Header.Set(DefaultTimestampHeader, strconv.FormatInt(ts, 10))
+	req.Header.Set(DefaultNonceHeader, nonce)
+	req.Header.Set(DefaultSignatureHeader, signature)
+	return req
+}
+
+func serve(r *gin.Engine, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestSignedRequestValid(t *testing.T) {
+	r := newSignedRouter(t, newMemoryNonces(t))
+	ts := time.Now().Unix()
+	body := `{"order":1}`
+
+	w := serve(r, signedRequest(body, ts, "n-1", SignRequest(testSecret, ts, "n-1", []byte(body))))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+	if w.Body.String() != body {
+		t.Fatalf("handler saw body %q, want %q", w.Body, body)
+	}
+}
+
+func TestSignedRequestReplayedNonce(t *testing.T) {
+	r := newSignedRouter(t, newMemoryNonces(t))
+	ts := time.Now().Unix()
+	body := `{"order":1}`
+	sig := SignRequest(testSecret, ts, "n-1", []byte(body))
+
+	if w := serve(r, signedRequest(body, ts, "n-1", sig)); w.Code != http.StatusOK {
+		t.Fatalf("first request status = %d", w.Code)
+	}
+	w := serve(r, signedRequest(body, ts, "n-1", sig))
+	if w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), "nonce already used") {
+		t.Fatalf("replay status = %d, body %s", w.Code, w.Body)
+	}
+}
+
+func TestSignedRequestRejects(t *testing.T) {
+	now := time.Now().Unix()
+	body := `{"order":1}`
+
+	tests := []struct {
+		name       string
+		req        *http.Request
+		wantStatus int
+	}{
+		{
+			name:       "expired timestamp",
+			req:        signedRequest(body, now-120, "n-old", SignRequest(testSecret, now-120, "n-old", []byte(body))),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "future timestamp",
+			req:        signedRequest(body, now+120, "n-future", SignRequest(testSecret, now+120, "n-future", []byte(body))),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "tampered body",
+			req:        signedRequest(`{"order":2}`, now, "n-2", SignRequest(testSecret, now, "n-2", []byte(body))),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "swapped nonce",
+			req:        signedRequest(body, now, "n-other", SignRequest(testSecret, now, "n-3", []byte(body))),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "wrong secret",
+			req:        signedRequest(body, now, "n-4", SignRequest("other", now, "n-4", []byte(body))),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "missing headers",
+			req:        httptest.NewRequest(http.MethodPost, "/hook", strings.NewReader(body)),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "malformed timestamp",
+			req: func() *http.Request {
+				req := signedRequest(body, now, "n-5", "sig")
+				req.Header.Set(DefaultTimestampHeader, "yesterday")
+				return req
+			}(),
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newSignedRouter(t, newMemoryNonces(t))
+			if w := serve(r, tt.req); w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body %s", w.Code, tt.wantStatus, w.Body)
+			}
+		})
+	}
+}
+
+func TestSignedRequestInvalidSignatureDoesNotBurnNonce(t *testing.T) {
+	r := newSignedRouter(t, newMemoryNonces(t))
+	ts := time.Now().Unix()
+	body := `{"order":1}`
+
+	if w := serve(r, signedRequest(body, ts, "n-1", "sha256=bogus")); w.Code != http.StatusUnauthorized {
+		t.Fatalf("forged status = %d", w.Code)
+	}
+	if w := serve(r, signedRequest(body, ts, "n-1", SignRequest(testSecret, ts, "n-1", []byte(body)))); w.Code != http.StatusOK {
+		t.Fatalf("genuine request after forgery status = %d, body %s", w.Code, w.Body)
+	}
+}
+
+type failingNonces struct{}
+
+func (failingNonces) Incr(context.Context, string, time.Duration) (int, time.Time, error) {
+	return 0, time.Time{}, errors.New("redis down")
+}
+
+func TestSignedRequestNonceStoreFailure(t *testing.T) {
+	r := newSignedRouter(t, failingNonces{})
+	ts := time.Now().Unix()
+	body := `{}`
+
+	w := serve(r, signedRequest(body, ts, "n-1", SignRequest(testSecret, ts, "n-1", []byte(body))))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", w.Code)
+	}
+}
@@ -0,0 +1,115 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrInvalidWindow is returned by CounterStore.Incr for a non-positive
+// window, which would otherwise restart the count on every call.
+var ErrInvalidWindow = errors.New("cache: counter window must be positive")
+
+// CounterStore counts events per key in fixed windows. It backs rate
+// limiting and similar "how many times in this window" checks; the
+// in-memory and Redis implementations are interchangeable.
+type CounterStore interface {
+	// Incr increments the counter for key and returns the new count with
+	// the time its window resets. The first call for a key, or the first
+	// call after its window has elapsed, starts a new window with count 1.
+	Incr(ctx context.Context, key string, window time.Duration) (count int, reset time.Time, err error)
+}
+
+type counterEntry struct {
+	count int
+	reset time.Time
+}
+
+// ExpiringCounterStore is the in-memory CounterStore. Expired windows are
+// purged by a background goroutine; call Stop to end it.
+type ExpiringCounterStore struct {
+	mu       sync.Mutex
+	counters map[string]*counterEntry
+	now      func() time.Time
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+var _ CounterStore = (*ExpiringCounterStore)(nil)
+
+// NewExpiringCounterStore creates a store that purges expired windows every
+// cleanupInterval. A non-positive interval defaults to one minute.
+func NewExpiringCounterStore(cleanupInterval time.Duration) *ExpiringCounterStore {
+	return newExpiringCounterStore(cleanupInterval, time.Now)
+}
+
+func newExpiringCounterStore(cleanupInterval time.Duration, now func() time.Time) *ExpiringCounterStore {
+	if cleanupInterval <= 0 {
+		cleanupInterval = time.Minute
+	}
+	s := &ExpiringCounterStore{
+		counters: make(map[string]*counterEntry),
+		now:      now,
+		stopCh:   make(chan struct{}),
+	}
+	go s.cleanup(cleanupInterval)
+	return s
+}
+
+func (s *ExpiringCounterStore) Incr(_ context.Context, key string, window time.Duration) (int, time.Time, error) {
+	if window <= 0 {
+		return 0, time.Time{}, ErrInvalidWindow
+	}
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.counters[key]
+	if !ok || !now.Before(entry.reset) {
+		entry = &counterEntry{reset: now.Add(window)}
+		s.counters[key] = entry
+	}
+	entry.count++
+	return entry.count, entry.reset, nil
+}
+
+// Size returns the number of keys currently tracked, including windows that
+// have elapsed but not yet been purged.
+func (s *ExpiringCounterStore) Size() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.counters)
+}
+
+// Stop terminates the cleanup goroutine. It is safe to call more than once.
+func (s *ExpiringCounterStore) Stop() {
+	s.stopOnce.Do(func() { close(s.stopCh) })
+}
+
+func (s *ExpiringCounterStore) cleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.purgeExpired()
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+func (s *ExpiringCounterStore) purgeExpired() {
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, entry := range s.counters {
+		if !now.Before(entry.reset) {
+			delete(s.counters, key)
+		}
+	}
+}
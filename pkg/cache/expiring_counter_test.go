@@ -0,0 +1,133 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced time source for window tests.
+type fakeClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.t = c.t.Add(d)
+	c.mu.Unlock()
+}
+
+func newTestCounterStore(t *testing.T) (*ExpiringCounterStore, *fakeClock) {
+	t.Helper()
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	s := newExpiringCounterStore(time.Hour, clock.Now)
+	t.Cleanup(s.Stop)
+	return s, clock
+}
+
+func mustIncr(t *testing.T, s CounterStore, key string, window time.Duration) (int, time.Time) {
+	t.Helper()
+	count, reset, err := s.Incr(context.Background(), key, window)
+	if err != nil {
+		t.Fatalf("Incr(%q): %v", key, err)
+	}
+	return count, reset
+}
+
+func TestExpiringCounterStoreWindowRollover(t *testing.T) {
+	s, clock := newTestCounterStore(t)
+	start := clock.Now()
+
+	for want := 1; want <= 3; want++ {
+		count, reset := mustIncr(t, s, "ip:1", time.Minute)
+		if count != want {
+			t.Fatalf("count = %d, want %d", count, want)
+		}
+		if !reset.Equal(start.Add(time.Minute)) {
+			t.Fatalf("reset = %v, want %v", reset, start.Add(time.Minute))
+		}
+		clock.Advance(10 * time.Second)
+	}
+
+	clock.Advance(time.Minute)
+	count, reset := mustIncr(t, s, "ip:1", time.Minute)
+	if count != 1 {
+		t.Fatalf("count after rollover = %d, want 1", count)
+	}
+	if !reset.Equal(clock.Now().Add(time.Minute)) {
+		t.Fatalf("reset after rollover = %v, want %v", reset, clock.Now().Add(time.Minute))
+	}
+}
+
+func TestExpiringCounterStoreKeysAreIndependent(t *testing.T) {
+	s, _ := newTestCounterStore(t)
+
+	mustIncr(t, s, "a", time.Minute)
+	mustIncr(t, s, "a", time.Minute)
+	if count, _ := mustIncr(t, s, "b", time.Minute); count != 1 {
+		t.Fatalf("count for b = %d, want 1", count)
+	}
+}
+
+func TestExpiringCounterStoreRejectsInvalidWindow(t *testing.T) {
+	s, _ := newTestCounterStore(t)
+
+	for _, window := range []time.Duration{0, -time.Second} {
+		if _, _, err := s.Incr(context.Background(), "k", window); !errors.Is(err, ErrInvalidWindow) {
+			t.Fatalf("Incr(window=%v) error = %v, want ErrInvalidWindow", window, err)
+		}
+	}
+	if got := s.Size(); got != 0 {
+		t.Fatalf("Size = %d, want 0 after rejected calls", got)
+	}
+}
+
+func TestExpiringCounterStorePurgeExpired(t *testing.T) {
+	s, clock := newTestCounterStore(t)
+
+	mustIncr(t, s, "short", time.Second)
+	mustIncr(t, s, "long", time.Hour)
+	clock.Advance(time.Minute)
+	s.purgeExpired()
+
+	if got := s.Size(); got != 1 {
+		t.Fatalf("Size = %d, want 1", got)
+	}
+}
+
+func TestExpiringCounterStoreConcurrentIncr(t *testing.T) {
+	s, _ := newTestCounterStore(t)
+	testConcurrentIncr(t, s)
+}
+
+func testConcurrentIncr(t *testing.T, s CounterStore) {
+	t.Helper()
+	const goroutines, perGoroutine = 50, 200
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perGoroutine; j++ {
+				if _, _, err := s.Incr(context.Background(), "shared", time.Minute); err != nil {
+					t.Errorf("Incr: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if count, _ := mustIncr(t, s, "shared", time.Minute); count != goroutines*perGoroutine+1 {
+		t.Fatalf("count = %d, want %d", count, goroutines*perGoroutine+1)
+	}
+}
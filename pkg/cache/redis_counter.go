@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// incrScript increments KEYS[1] and starts its window on first use. A key
+// that somehow lost its expiry is given one again so it cannot count forever.
+// Returns {count, remaining window in ms}.
+var incrScript = redis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+if count == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+local ttl = redis.call('PTTL', KEYS[1])
+if ttl < 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+	ttl = tonumber(ARGV[1])
+end
+return {count, ttl}
+`)
+
+// RedisCounterStore is a CounterStore shared across instances through Redis.
+// Each key is incremented and expired atomically in a single script call.
+type RedisCounterStore struct {
+	client redis.Scripter
+	prefix string
+	now    func() time.Time
+}
+
+var _ CounterStore = (*RedisCounterStore)(nil)
+
+// NewRedisCounterStore creates a store on client. prefix is prepended to
+// every key to keep counters apart from other data in the same database.
+func NewRedisCounterStore(client redis.Scripter, prefix string) *RedisCounterStore {
+	return &RedisCounterStore{client: client, prefix: prefix, now: time.Now}
+}
+
+func (s *RedisCounterStore) Incr(ctx context.Context, key string, window time.Duration) (int, time.Time, error) {
+	if window <= 0 {
+		return 0, time.Time{}, ErrInvalidWindow
+	}
+
+	res, err := incrScript.Run(ctx, s.client, []string{s.prefix + key}, window.Milliseconds()).Int64Slice()
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("cache: redis counter incr %q: %w", key, err)
+	}
+	if len(res) != 2 {
+		return 0, time.Time{}, fmt.Errorf("cache: redis counter incr %q: unexpected reply %v", key, res)
+	}
+	return int(res[0]), s.now().Add(time.Duration(res[1]) * time.Millisecond), nil
+}
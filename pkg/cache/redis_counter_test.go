@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestRedisCounterStore(t *testing.T) (*RedisCounterStore, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewRedisCounterStore(client, "rl:"), mr
+}
+
+func TestRedisCounterStoreWindowRollover(t *testing.T) {
+	s, mr := newTestRedisCounterStore(t)
+
+	for want := 1; want <= 3; want++ {
+		if count, _ := mustIncr(t, s, "ip:1", time.Minute); count != want {
+			t.Fatalf("count = %d, want %d", count, want)
+		}
+	}
+	if ttl := mr.TTL("rl:ip:1"); ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("TTL = %v, want within (0, 1m]", ttl)
+	}
+
+	mr.FastForward(time.Minute)
+	before := time.Now()
+	count, reset := mustIncr(t, s, "ip:1", time.Minute)
+	if count != 1 {
+		t.Fatalf("count after rollover = %d, want 1", count)
+	}
+	if reset.Before(before.Add(time.Minute - time.Second)) {
+		t.Fatalf("reset = %v, want about one minute from now", reset)
+	}
+}
+
+func TestRedisCounterStoreRestoresMissingExpiry(t *testing.T) {
+	s, mr := newTestRedisCounterStore(t)
+
+	mr.Set("rl:stuck", "5")
+	if count, _ := mustIncr(t, s, "stuck", time.Minute); count != 6 {
+		t.Fatalf("count = %d, want 6", count)
+	}
+	if ttl := mr.TTL("rl:stuck"); ttl <= 0 {
+		t.Fatalf("TTL = %v, want expiry to be set", ttl)
+	}
+}
+
+func TestRedisCounterStoreRejectsInvalidWindow(t *testing.T) {
+	s, _ := newTestRedisCounterStore(t)
+
+	if _, _, err := s.Incr(context.Background(), "k", 0); !errors.Is(err, ErrInvalidWindow) {
+		t.Fatalf("Incr(window=0) error = %v, want ErrInvalidWindow", err)
+	}
+}
+
+func TestRedisCounterStoreBackendError(t *testing.T) {
+	s, mr := newTestRedisCounterStore(t)
+	mr.Close()
+
+	if _, _, err := s.Incr(context.Background(), "k", time.Minute); err == nil {
+		t.Fatal("expected error when redis is unavailable")
+	}
+}
+
+func TestRedisCounterStoreConcurrentIncr(t *testing.T) {
+	s, _ := newTestRedisCounterStore(t)
+	testConcurrentIncr(t, s)
+}
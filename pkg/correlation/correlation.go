@@ -0,0 +1,21 @@
+// Package correlation carries the request correlation ID through
+// context.Context so outbound calls can forward it.
+package correlation
+
+import "context"
+
+// Header is the HTTP header used to exchange the correlation ID.
+const Header = "X-Correlation-ID"
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying id.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the correlation ID stored in ctx, or "" if none.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
@@ -0,0 +1,16 @@
+package correlation
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFromContext(t *testing.T) {
+	if got := FromContext(context.Background()); got != "" {
+		t.Fatalf("FromContext(empty) = %q, want empty", got)
+	}
+	ctx := NewContext(context.Background(), "abc-123")
+	if got := FromContext(ctx); got != "abc-123" {
+		t.Fatalf("FromContext = %q, want abc-123", got)
+	}
+}
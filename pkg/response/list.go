@@ -0,0 +1,30 @@
+// Package response holds the shapes handlers return to API clients.
+package response
+
+// List is the envelope data for paginated list endpoints. Page is 1-based.
+type List[T any] struct {
+	Items    []T  `json:"items"`
+	Total    int  `json:"total"`
+	Page     int  `json:"page"`
+	PageSize int  `json:"page_size"`
+	HasNext  bool `json:"has_next"`
+}
+
+// NewList builds a List for one page of a result set holding total items.
+// HasNext is true when items exist beyond this page. A nil items slice is
+// serialized as an empty array so clients always receive a list.
+func NewList[T any](items []T, total, page, pageSize int) List[T] {
+	if items == nil {
+		items = []T{}
+	}
+	if page < 1 {
+		page = 1
+	}
+	return List[T]{
+		Items:    items,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+		HasNext:  pageSize > 0 && page*pageSize < total,
+	}
+}
@@ -0,0 +1,58 @@
+package response
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNewListJSON(t *testing.T) {
+	type item struct {
+		ID int `json:"id"`
+	}
+
+	got, err := json.Marshal(NewList([]item{{ID: 1}, {ID: 2}}, 5, 1, 2))
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	want := `{"items":[{"id":1},{"id":2}],"total":5,"page":1,"page_size":2,"has_next":true}`
+	if string(got) != want {
+		t.Fatalf("JSON = %s, want %s", got, want)
+	}
+
+	got, err = json.Marshal(NewList[item](nil, 0, 1, 20))
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	want = `{"items":[],"total":0,"page":1,"page_size":20,"has_next":false}`
+	if string(got) != want {
+		t.Fatalf("empty JSON = %s, want %s", got, want)
+	}
+}
+
+func TestNewListHasNext(t *testing.T) {
+	tests := []struct {
+		name                  string
+		total, page, pageSize int
+		want                  bool
+	}{
+		{name: "first of several pages", total: 25, page: 1, pageSize: 10, want: true},
+		{name: "second to last page", total: 25, page: 2, pageSize: 10, want: true},
+		{name: "partial last page", total: 25, page: 3, pageSize: 10, want: false},
+		{name: "exactly full last page", total: 20, page: 2, pageSize: 10, want: false},
+		{name: "one item over a full page", total: 21, page: 2, pageSize: 10, want: true},
+		{name: "single page", total: 10, page: 1, pageSize: 10, want: false},
+		{name: "past the end", total: 5, page: 4, pageSize: 10, want: false},
+		{name: "empty", total: 0, page: 1, pageSize: 10, want: false},
+		{name: "page below one treated as first", total: 25, page: 0, pageSize: 10, want: true},
+		{name: "zero page size", total: 25, page: 1, pageSize: 0, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewList([]int{}, tt.total, tt.page, tt.pageSize).HasNext; got != tt.want {
+				t.Fatalf("HasNext(total=%d, page=%d, size=%d) = %v, want %v",
+					tt.total, tt.page, tt.pageSize, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,267 @@
+// Package webhook delivers signed JSON payloads to customer endpoints with
+// retries and exponential backoff.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/VanDuc0209/gin-clean-template/pkg/correlation"
+	"github.com/VanDuc0209/gin-clean-template/pkg/workerpool"
+)
+
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+)
+
+var (
+	ErrQueueFull = workerpool.ErrQueueFull
+	ErrStopped   = workerpool.ErrStopped
+)
+
+// Endpoint is a delivery target with its own signing secret.
+type Endpoint struct {
+	URL    string
+	Secret string
+}
+
+// Attempt records a single HTTP delivery attempt.
+type Attempt struct {
+	Number     int
+	At         time.Time
+	Duration   time.Duration
+	StatusCode int
+	Err        error
+}
+
+// Delivery is the outcome of delivering one payload, including every attempt.
+type Delivery struct {
+	URL       string
+	Attempts  []Attempt
+	Delivered bool
+}
+
+type Config struct {
+	// MaxAttempts is the total number of tries, including the first one.
+	MaxAttempts int
+	// BaseBackoff is the wait before the first retry; it doubles on each
+	// further retry up to MaxBackoff.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// AttemptTimeout bounds each individual HTTP request.
+	AttemptTimeout time.Duration
+	// Workers and QueueSize size the pool used by Dispatch.
+	Workers   int
+	QueueSize int
+	// OnDelivery, when set, is called with the record of every finished
+	// delivery, successful or not. It may run on pool goroutines.
+	OnDelivery func(Delivery)
+	// OnPanic, when set, receives the value and stack of a panic raised
+	// during an asynchronous delivery (including from OnDelivery).
+	OnPanic func(recovered any, stack []byte)
+}
+
+func (c Config) withDefaults() Config {
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = 5
+	}
+	if c.BaseBackoff <= 0 {
+		c.BaseBackoff = 500 * time.Millisecond
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = 30 * time.Second
+	}
+	if c.AttemptTimeout <= 0 {
+		c.AttemptTimeout = 10 * time.Second
+	}
+	if c.Workers <= 0 {
+		c.Workers = 4
+	}
+	if c.QueueSize <= 0 {
+		c.QueueSize = 100
+	}
+	return c
+}
+
+// Dispatcher sends webhooks synchronously via Send or asynchronously via
+// Dispatch.
+type Dispatcher struct {
+	client *http.Client
+	cfg    Config
+	pool   *workerpool.Pool
+}
+
+// NewDispatcher creates a dispatcher using client for HTTP calls. A nil
+// client falls back to http.DefaultClient.
+func NewDispatcher(client *http.Client, cfg Config) *Dispatcher {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	cfg = cfg.withDefaults()
+
+	return &Dispatcher{
+		client: client,
+		cfg:    cfg,
+		pool: workerpool.New(workerpool.Config{
+			Workers:   cfg.Workers,
+			QueueSize: cfg.QueueSize,
+			OnPanic:   cfg.OnPanic,
+		}),
+	}
+}
+
+// Sign returns the hex HMAC-SHA256 of "<timestamp>.<body>" keyed by secret,
+// prefixed with "sha256=". Receivers recompute it to authenticate a delivery.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Send delivers payload to endpoint, retrying on network errors, timeouts,
+// 429 and 5xx responses. It blocks until the delivery succeeds, fails
+// permanently, runs out of attempts or ctx is done.
+func (d *Dispatcher) Send(ctx context.Context, endpoint Endpoint, payload any) (Delivery, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return Delivery{URL: endpoint.URL}, fmt.Errorf("webhook: marshal payload: %w", err)
+	}
+	delivery, err := d.deliver(ctx, endpoint, body)
+	d.report(delivery)
+	return delivery, err
+}
+
+// Dispatch queues payload for asynchronous delivery. It never blocks: when
+// the queue is full it returns ErrQueueFull. The delivery outlives ctx; only
+// request-scoped values such as the correlation ID are taken from it.
+func (d *Dispatcher) Dispatch(ctx context.Context, endpoint Endpoint, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("webhook: marshal payload: %w", err)
+	}
+	correlationID := correlation.FromContext(ctx)
+
+	return d.pool.Submit(func(poolCtx context.Context) {
+		if correlationID != "" {
+			poolCtx = correlation.NewContext(poolCtx, correlationID)
+		}
+		delivery, _ := d.deliver(poolCtx, endpoint, body)
+		d.report(delivery)
+	})
+}
+
+func (d *Dispatcher) report(delivery Delivery) {
+	if d.cfg.OnDelivery != nil {
+		d.cfg.OnDelivery(delivery)
+	}
+}
+
+// Stop stops accepting new deliveries and waits for queued ones to finish.
+// If ctx expires first, in-flight retries are cancelled and ctx.Err() is
+// returned.
+func (d *Dispatcher) Stop(ctx context.Context) error {
+	return d.pool.Stop(ctx)
+}
+
+// Stats reports the state of the asynchronous delivery queue.
+func (d *Dispatcher) Stats() workerpool.Stats {
+	return d.pool.Stats()
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, endpoint Endpoint, body []byte) (Delivery, error) {
+	delivery := Delivery{URL: endpoint.URL}
+
+	var lastErr error
+	for n := 1; n <= d.cfg.MaxAttempts; n++ {
+		if n > 1 {
+			if err := sleep(ctx, d.backoff(n-1)); err != nil {
+				return delivery, err
+			}
+		}
+
+		attempt, retry := d.attempt(ctx, endpoint, body, n)
+		delivery.Attempts = append(delivery.Attempts, attempt)
+		if attempt.Err == nil && attempt.StatusCode < 300 {
+			delivery.Delivered = true
+			return delivery, nil
+		}
+
+		lastErr = attempt.Err
+		if lastErr == nil {
+			lastErr = fmt.Errorf("webhook: %s responded %d", endpoint.URL, attempt.StatusCode)
+		}
+		if !retry || ctx.Err() != nil {
+			break
+		}
+	}
+	return delivery, lastErr
+}
+
+func (d *Dispatcher) attempt(ctx context.Context, endpoint Endpoint, body []byte, n int) (Attempt, bool) {
+	start := time.Now()
+	a := Attempt{Number: n, At: start}
+
+	reqCtx, cancel := context.WithTimeout(ctx, d.cfg.AttemptTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		a.Err = fmt.Errorf("webhook: build request: %w", err)
+		return a, false
+	}
+	ts := start.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(TimestampHeader, strconv.FormatInt(ts, 10))
+	req.Header.Set(SignatureHeader, Sign(endpoint.Secret, ts, body))
+	if id := correlation.FromContext(ctx); id != "" {
+		req.Header.Set(correlation.Header, id)
+	}
+
+	resp, err := d.client.Do(req)
+	a.Duration = time.Since(start)
+	if err != nil {
+		a.Err = err
+		return a, true
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+
+	a.StatusCode = resp.StatusCode
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	return a, retry
+}
+
+// backoff returns the wait before retry number n (1-based).
+func (d *Dispatcher) backoff(n int) time.Duration {
+	wait := min(d.cfg.BaseBackoff, d.cfg.MaxBackoff)
+	for i := 1; i < n; i++ {
+		wait *= 2
+		if wait >= d.cfg.MaxBackoff {
+			return d.cfg.MaxBackoff
+		}
+	}
+	return wait
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
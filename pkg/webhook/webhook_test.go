@@ -0,0 +1,269 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/VanDuc0209/gin-clean-template/pkg/correlation"
+)
+
+func testConfig() Config {
+	return Config{MaxAttempts: 3, BaseBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+}
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"event":"order.paid"}`)
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte("1700000000." + string(body)))
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	if got := Sign("s3cret", 1700000000, body); got != want {
+		t.Fatalf("Sign = %s, want %s", got, want)
+	}
+	if Sign("other", 1700000000, body) == want {
+		t.Fatal("signature must depend on the secret")
+	}
+	if Sign("s3cret", 1700000001, body) == want {
+		t.Fatal("signature must depend on the timestamp")
+	}
+}
+
+func TestSendSignsRequest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ts, err := strconv.ParseInt(r.Header.Get(TimestampHeader), 10, 64)
+		if err != nil {
+			t.Errorf("bad timestamp header: %v", err)
+		}
+		if got, want := r.Header.Get(SignatureHeader), Sign("s3cret", ts, body); got != want {
+			t.Errorf("signature header = %s, want %s", got, want)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %s", ct)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	d := NewDispatcher(srv.Client(), testConfig())
+	defer d.Stop(context.Background())
+
+	delivery, err := d.Send(context.Background(), Endpoint{URL: srv.URL, Secret: "s3cret"}, map[string]string{"event": "order.paid"})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if !delivery.Delivered || len(delivery.Attempts) != 1 {
+		t.Fatalf("delivery = %+v", delivery)
+	}
+}
+
+func TestSendRetriesThenSucceeds(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	d := NewDispatcher(srv.Client(), testConfig())
+	defer d.Stop(context.Background())
+
+	delivery, err := d.Send(context.Background(), Endpoint{URL: srv.URL, Secret: "k"}, "ping")
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if !delivery.Delivered {
+		t.Fatal("expected delivery to succeed")
+	}
+	if len(delivery.Attempts) != 3 {
+		t.Fatalf("attempts = %d, want 3", len(delivery.Attempts))
+	}
+	for i, a := range delivery.Attempts[:2] {
+		if a.StatusCode != http.StatusBadGateway || a.Number != i+1 {
+			t.Errorf("attempt %d = %+v", i, a)
+		}
+	}
+}
+
+func TestSendRetriesOnTimeout(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			time.Sleep(100 * time.Millisecond)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	cfg := testConfig()
+	cfg.AttemptTimeout = 20 * time.Millisecond
+	d := NewDispatcher(srv.Client(), cfg)
+	defer d.Stop(context.Background())
+
+	delivery, err := d.Send(context.Background(), Endpoint{URL: srv.URL}, "ping")
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if len(delivery.Attempts) != 2 || delivery.Attempts[0].Err == nil {
+		t.Fatalf("attempts = %+v", delivery.Attempts)
+	}
+}
+
+func TestSendDoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	d := NewDispatcher(srv.Client(), testConfig())
+	defer d.Stop(context.Background())
+
+	delivery, err := d.Send(context.Background(), Endpoint{URL: srv.URL}, "ping")
+	if err == nil || delivery.Delivered {
+		t.Fatal("expected permanent failure")
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("calls = %d, want 1", calls.Load())
+	}
+}
+
+func TestDispatchDeliversBeforeStop(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	var (
+		mu         sync.Mutex
+		deliveries []Delivery
+	)
+	cfg := testConfig()
+	cfg.OnDelivery = func(del Delivery) {
+		mu.Lock()
+		deliveries = append(deliveries, del)
+		mu.Unlock()
+	}
+	d := NewDispatcher(srv.Client(), cfg)
+
+	for i := 0; i < 10; i++ {
+		if err := d.Dispatch(context.Background(), Endpoint{URL: srv.URL}, i); err != nil {
+			t.Fatalf("Dispatch %d: %v", i, err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := d.Stop(ctx); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if len(deliveries) != 10 {
+		t.Fatalf("deliveries = %d, want 10", len(deliveries))
+	}
+	if err := d.Dispatch(context.Background(), Endpoint{URL: srv.URL}, "late"); err != ErrStopped {
+		t.Fatalf("Dispatch after Stop = %v, want ErrStopped", err)
+	}
+}
+
+func TestDispatchPropagatesCorrelationID(t *testing.T) {
+	got := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got <- r.Header.Get(correlation.Header)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	d := NewDispatcher(srv.Client(), testConfig())
+	defer d.Stop(context.Background())
+
+	// The request context is already cancelled by the time the delivery
+	// runs; only its correlation ID should carry over.
+	ctx, cancel := context.WithCancel(correlation.NewContext(context.Background(), "req-42"))
+	if err := d.Dispatch(ctx, Endpoint{URL: srv.URL}, "ping"); err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	cancel()
+
+	select {
+	case id := <-got:
+		if id != "req-42" {
+			t.Fatalf("%s = %q, want req-42", correlation.Header, id)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("delivery not received")
+	}
+}
+
+func TestSendOmitsCorrelationIDWhenAbsent(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Header[correlation.Header]; ok {
+			t.Errorf("unexpected %s header", correlation.Header)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	d := NewDispatcher(srv.Client(), testConfig())
+	defer d.Stop(context.Background())
+
+	if _, err := d.Send(context.Background(), Endpoint{URL: srv.URL}, "ping"); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+}
+
+func TestBackoffClampedToMax(t *testing.T) {
+	d := &Dispatcher{cfg: Config{BaseBackoff: time.Minute, MaxBackoff: time.Second}}
+	for n := 1; n <= 3; n++ {
+		if got := d.backoff(n); got != time.Second {
+			t.Fatalf("backoff(%d) = %v, want 1s", n, got)
+		}
+	}
+
+	d.cfg = Config{BaseBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second}
+	for i, w := range want {
+		if got := d.backoff(i + 1); got != w {
+			t.Fatalf("backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+}
+
+func TestDispatchReportsPanics(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	panicked := make(chan any, 1)
+	cfg := testConfig()
+	cfg.OnDelivery = func(Delivery) { panic("broken hook") }
+	cfg.OnPanic = func(r any, _ []byte) { panicked <- r }
+	d := NewDispatcher(srv.Client(), cfg)
+	defer d.Stop(context.Background())
+
+	if err := d.Dispatch(context.Background(), Endpoint{URL: srv.URL}, "ping"); err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	select {
+	case r := <-panicked:
+		if r != "broken hook" {
+			t.Fatalf("OnPanic value = %v", r)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("OnPanic not called")
+	}
+}
@@ -0,0 +1,191 @@
+// Package workerpool runs asynchronous tasks on a fixed number of goroutines
+// fed by a bounded queue, so background work applies backpressure instead of
+// spawning unbounded goroutines.
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	ErrQueueFull = errors.New("workerpool: queue is full")
+	ErrStopped   = errors.New("workerpool: pool is stopped")
+)
+
+// Task is a unit of work. ctx is cancelled when Stop gives up waiting, so
+// long-running tasks should watch it.
+type Task func(ctx context.Context)
+
+type Config struct {
+	Workers   int
+	QueueSize int
+	// BlockWhenFull makes Submit wait for queue space instead of returning
+	// ErrQueueFull.
+	BlockWhenFull bool
+	// OnPanic, when set, receives the value and stack of a panicking task.
+	// The worker survives the panic either way.
+	OnPanic func(recovered any, stack []byte)
+}
+
+// Stats is a point-in-time snapshot of pool activity.
+type Stats struct {
+	QueueDepth    int
+	QueueCapacity int
+	Submitted     uint64
+	Rejected      uint64
+	Completed     uint64
+	Panicked      uint64
+}
+
+type Pool struct {
+	cfg    Config
+	queue  chan Task
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// mu guards stopped and the Add side of senders; it is never held
+	// while sending to queue.
+	mu      sync.Mutex
+	stopped bool
+	quit    chan struct{}
+	senders sync.WaitGroup
+	workers sync.WaitGroup
+	once    sync.Once
+	done    chan struct{}
+
+	submitted atomic.Uint64
+	rejected  atomic.Uint64
+	completed atomic.Uint64
+	panicked  atomic.Uint64
+}
+
+// New starts a pool. Non-positive Workers defaults to 1 and negative
+// QueueSize to 0 (an unbuffered hand-off to idle workers).
+func New(cfg Config) *Pool {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.QueueSize < 0 {
+		cfg.QueueSize = 0
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	p := &Pool{
+		cfg:    cfg,
+		queue:  make(chan Task, cfg.QueueSize),
+		ctx:    ctx,
+		cancel: cancel,
+		quit:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	for i := 0; i < cfg.Workers; i++ {
+		p.workers.Add(1)
+		go p.worker()
+	}
+	return p
+}
+
+// Submit queues task. When the queue is full it returns ErrQueueFull, or
+// waits for space if BlockWhenFull is set. Once Stop has been called it
+// returns ErrStopped, including for a Submit still waiting for space.
+func (p *Pool) Submit(task Task) error {
+	p.mu.Lock()
+	if p.stopped {
+		p.mu.Unlock()
+		p.rejected.Add(1)
+		return ErrStopped
+	}
+	p.senders.Add(1)
+	p.mu.Unlock()
+	defer p.senders.Done()
+
+	if p.cfg.BlockWhenFull {
+		select {
+		case p.queue <- task:
+			p.submitted.Add(1)
+			return nil
+		case <-p.quit:
+			p.rejected.Add(1)
+			return ErrStopped
+		}
+	}
+	select {
+	case p.queue <- task:
+		p.submitted.Add(1)
+		return nil
+	default:
+		p.rejected.Add(1)
+		return ErrQueueFull
+	}
+}
+
+// QueueDepth returns the number of tasks waiting for a worker.
+func (p *Pool) QueueDepth() int {
+	return len(p.queue)
+}
+
+func (p *Pool) Stats() Stats {
+	return Stats{
+		QueueDepth:    len(p.queue),
+		QueueCapacity: cap(p.queue),
+		Submitted:     p.submitted.Load(),
+		Rejected:      p.rejected.Load(),
+		Completed:     p.completed.Load(),
+		Panicked:      p.panicked.Load(),
+	}
+}
+
+// Stop stops accepting tasks before it returns, then waits until every
+// accepted task has run. If ctx expires first, the context passed to tasks
+// is cancelled and ctx.Err() is returned; the workers still drain the queue
+// in the background.
+func (p *Pool) Stop(ctx context.Context) error {
+	p.once.Do(func() {
+		p.mu.Lock()
+		p.stopped = true
+		close(p.quit)
+		p.mu.Unlock()
+
+		go func() {
+			// Submits that got past the stopped check either enqueue or
+			// bail out on quit; only then is it safe to close the queue.
+			p.senders.Wait()
+			close(p.queue)
+			p.workers.Wait()
+			close(p.done)
+		}()
+	})
+
+	select {
+	case <-p.done:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		return ctx.Err()
+	}
+}
+
+func (p *Pool) worker() {
+	defer p.workers.Done()
+	for task := range p.queue {
+		p.run(task)
+	}
+}
+
+func (p *Pool) run(task Task) {
+	defer func() {
+		if r := recover(); r != nil {
+			p.panicked.Add(1)
+			if p.cfg.OnPanic != nil {
+				p.cfg.OnPanic(r, debug.Stack())
+			}
+		}
+		p.completed.Add(1)
+	}()
+	task(p.ctx)
+}
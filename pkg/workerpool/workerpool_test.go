@@ -0,0 +1,227 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSubmitRejectsWhenQueueFull(t *testing.T) {
+	p := New(Config{Workers: 1, QueueSize: 2})
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	if err := p.Submit(func(context.Context) { close(started); <-release }); err != nil {
+		t.Fatalf("Submit busy task: %v", err)
+	}
+	<-started
+	for i := 0; i < 2; i++ {
+		if err := p.Submit(func(context.Context) {}); err != nil {
+			t.Fatalf("Submit %d: %v", i, err)
+		}
+	}
+
+	if err := p.Submit(func(context.Context) {}); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("Submit on full queue = %v, want ErrQueueFull", err)
+	}
+	if s := p.Stats(); s.QueueDepth != 2 || s.Rejected != 1 {
+		t.Fatalf("Stats = %+v", s)
+	}
+
+	close(release)
+	if err := p.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+}
+
+func TestSubmitBlocksWhenConfigured(t *testing.T) {
+	p := New(Config{Workers: 1, QueueSize: 1, BlockWhenFull: true})
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	p.Submit(func(context.Context) { close(started); <-release })
+	<-started
+	p.Submit(func(context.Context) {})
+
+	submitted := make(chan error, 1)
+	go func() { submitted <- p.Submit(func(context.Context) {}) }()
+
+	select {
+	case err := <-submitted:
+		t.Fatalf("Submit returned %v while queue was full", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case err := <-submitted:
+		if err != nil {
+			t.Fatalf("blocked Submit: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("blocked Submit did not proceed after space freed")
+	}
+
+	if err := p.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+}
+
+func TestStopDrainsQueue(t *testing.T) {
+	p := New(Config{Workers: 2, QueueSize: 50})
+	var ran atomic.Int32
+	for i := 0; i < 50; i++ {
+		if err := p.Submit(func(context.Context) {
+			time.Sleep(time.Millisecond)
+			ran.Add(1)
+		}); err != nil {
+			t.Fatalf("Submit %d: %v", i, err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := p.Stop(ctx); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if ran.Load() != 50 {
+		t.Fatalf("ran = %d, want 50", ran.Load())
+	}
+	if err := p.Submit(func(context.Context) {}); !errors.Is(err, ErrStopped) {
+		t.Fatalf("Submit after Stop = %v, want ErrStopped", err)
+	}
+}
+
+func TestStopTimeoutCancelsTasks(t *testing.T) {
+	p := New(Config{Workers: 1, QueueSize: 1})
+	cancelled := make(chan struct{})
+	p.Submit(func(ctx context.Context) {
+		<-ctx.Done()
+		close(cancelled)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := p.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Stop = %v, want DeadlineExceeded", err)
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("task context was not cancelled after Stop timed out")
+	}
+}
+
+func TestSubmitAfterStopWithExpiredContext(t *testing.T) {
+	for _, block := range []bool{false, true} {
+		p := New(Config{Workers: 1, QueueSize: 4, BlockWhenFull: block})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		p.Stop(ctx)
+
+		var ran atomic.Int32
+		for i := 0; i < 1000; i++ {
+			if err := p.Submit(func(context.Context) { ran.Add(1) }); !errors.Is(err, ErrStopped) {
+				t.Fatalf("block=%v: Submit %d after Stop = %v, want ErrStopped", block, i, err)
+			}
+		}
+		if ran.Load() != 0 {
+			t.Fatalf("block=%v: %d tasks ran after Stop", block, ran.Load())
+		}
+	}
+}
+
+func TestStopReleasesBlockedSubmit(t *testing.T) {
+	p := New(Config{Workers: 1, QueueSize: 1, BlockWhenFull: true})
+	release := make(chan struct{})
+	started := make(chan struct{})
+	p.Submit(func(context.Context) { close(started); <-release })
+	<-started
+	p.Submit(func(context.Context) {})
+
+	submitted := make(chan error, 1)
+	go func() { submitted <- p.Submit(func(context.Context) {}) }()
+	time.Sleep(20 * time.Millisecond)
+
+	stopped := make(chan error, 1)
+	go func() { stopped <- p.Stop(context.Background()) }()
+
+	select {
+	case err := <-submitted:
+		if err != nil && !errors.Is(err, ErrStopped) {
+			t.Fatalf("blocked Submit = %v, want nil or ErrStopped", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("blocked Submit was not released by Stop")
+	}
+
+	close(release)
+	if err := <-stopped; err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+}
+
+func TestConcurrentSubmitAndStopRunsAcceptedTasks(t *testing.T) {
+	p := New(Config{Workers: 4, QueueSize: 16})
+	var accepted, ran atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				if p.Submit(func(context.Context) { ran.Add(1) }) == nil {
+					accepted.Add(1)
+				}
+			}
+		}()
+	}
+	time.Sleep(time.Millisecond)
+	if err := p.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	wg.Wait()
+
+	if ran.Load() != accepted.Load() {
+		t.Fatalf("ran %d of %d accepted tasks", ran.Load(), accepted.Load())
+	}
+}
+
+func TestPanickingTaskDoesNotKillWorker(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		recovered any
+		stack     []byte
+	)
+	p := New(Config{Workers: 1, QueueSize: 2, OnPanic: func(r any, s []byte) {
+		mu.Lock()
+		recovered, stack = r, s
+		mu.Unlock()
+	}})
+	var ran atomic.Bool
+	p.Submit(func(context.Context) { panic("boom") })
+	p.Submit(func(context.Context) { ran.Store(true) })
+
+	if err := p.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if !ran.Load() {
+		t.Fatal("task after panic did not run")
+	}
+	if s := p.Stats(); s.Panicked != 1 || s.Completed != 2 {
+		t.Fatalf("Stats = %+v", s)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if recovered != "boom" {
+		t.Fatalf("OnPanic value = %v, want boom", recovered)
+	}
+	if !strings.Contains(string(stack), "workerpool_test.go") {
+		t.Fatalf("OnPanic stack does not include the panicking task:\n%s", stack)
+	}
+}
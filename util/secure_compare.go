@@ -0,0 +1,10 @@
+package util
+
+import "crypto/subtle"
+
+// SecureCompare reports whether a and b are equal in constant time, for
+// comparing secrets such as API keys, CSRF tokens and signatures. Only the
+// lengths of the inputs can leak through timing, not their contents.
+func SecureCompare(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
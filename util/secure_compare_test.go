@@ -0,0 +1,25 @@
+package util
+
+import "testing"
+
+func TestSecureCompare(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want bool
+	}{
+		{name: "equal", a: "s3cret-token", b: "s3cret-token", want: true},
+		{name: "both empty", a: "", b: "", want: true},
+		{name: "unequal same length", a: "s3cret-token", b: "s3cret-tokeN", want: false},
+		{name: "different length", a: "s3cret", b: "s3cret-token", want: false},
+		{name: "one empty", a: "", b: "x", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SecureCompare(tt.a, tt.b); got != tt.want {
+				t.Fatalf("SecureCompare(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SortField is one validated ordering term produced by ParseSort.
+type SortField struct {
+	// Column is the safe column name taken from the allowlist, never the raw
+	// client input.
+	Column string
+	Desc   bool
+}
+
+// ParseSort parses a sort expression such as "-created_at,name". Fields are
+// comma-separated and a leading "-" means descending. Each API field name
+// must be a key of allowed, which maps it to the column name to sort on, so
+// the result is safe to interpolate into ORDER BY / sort documents.
+// An empty expression yields no fields.
+func ParseSort(raw string, allowed map[string]string) ([]SortField, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(raw, ",")
+	fields := make([]SortField, 0, len(parts))
+	seen := make(map[string]struct{}, len(parts))
+	for _, part := range parts {
+		name := strings.TrimSpace(part)
+		desc := strings.HasPrefix(name, "-")
+		name = strings.TrimPrefix(name, "-")
+		if name == "" {
+			return nil, fmt.Errorf("invalid sort expression %q: empty field", raw)
+		}
+
+		column, ok := allowed[name]
+		if !ok {
+			return nil, fmt.Errorf("sorting by %q is not allowed", name)
+		}
+		if _, dup := seen[name]; dup {
+			return nil, fmt.Errorf("sort field %q given more than once", name)
+		}
+		seen[name] = struct{}{}
+
+		fields = append(fields, SortField{Column: column, Desc: desc})
+	}
+	return fields, nil
+}
@@ -0,0 +1,67 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+)
+
+var sortAllowlist = map[string]string{
+	"name":       "full_name",
+	"created_at": "created_at",
+	"age":        "age_years",
+}
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want []SortField
+	}{
+		{name: "empty", raw: "", want: nil},
+		{name: "single ascending", raw: "name", want: []SortField{{Column: "full_name"}}},
+		{name: "descending", raw: "-created_at", want: []SortField{{Column: "created_at", Desc: true}}},
+		{
+			name: "multi field",
+			raw:  "-created_at, name,-age",
+			want: []SortField{
+				{Column: "created_at", Desc: true},
+				{Column: "full_name"},
+				{Column: "age_years", Desc: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSort(tt.raw, sortAllowlist)
+			if err != nil {
+				t.Fatalf("ParseSort(%q) error: %v", tt.raw, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ParseSort(%q) = %+v, want %+v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSortRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{name: "not allowlisted", raw: "name,password"},
+		{name: "injection attempt", raw: "name; DROP TABLE users"},
+		{name: "column name not api name", raw: "full_name"},
+		{name: "empty field", raw: "name,,age"},
+		{name: "bare dash", raw: "-"},
+		{name: "duplicate", raw: "name,-name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := ParseSort(tt.raw, sortAllowlist); err == nil {
+				t.Fatalf("ParseSort(%q) = %+v, want error", tt.raw, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,466 @@
+package util
+
+import (
+	"encoding"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// StructToMap converts a struct (or pointer to struct) into a map keyed by
+// json field names. Nested structs become nested maps and embedded structs
+// are flattened with the same name-dominance rules as encoding/json.
+// time.Time values are kept as-is; other encoding.TextMarshaler values (such
+// as decimal types) become their text form.
+func StructToMap(v any) (map[string]any, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, fmt.Errorf("util: StructToMap of nil pointer")
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("util: StructToMap expects a struct, got %s", rv.Kind())
+	}
+	return structToMap(rv)
+}
+
+// MapToStruct fills the struct pointed to by out from a map keyed by json
+// field names. Values are converted where safe: numeric kinds within range,
+// strings into encoding.TextUnmarshaler fields (RFC 3339 for time.Time), and
+// nested maps and slices into nested structs, maps and slices. Keys without
+// a matching field are ignored.
+func MapToStruct(m map[string]any, out any) error {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("util: MapToStruct expects a non-nil pointer to struct")
+	}
+	return mapToStruct(m, rv.Elem())
+}
+
+type structField struct {
+	name      string
+	tagged    bool
+	omitEmpty bool
+	index     []int
+}
+
+var fieldCache sync.Map // map[reflect.Type][]structField
+
+// isEmbeddedStruct reports whether f should be flattened into its parent.
+func isEmbeddedStruct(f reflect.StructField) bool {
+	if !f.Anonymous || f.Tag.Get("json") != "" {
+		return false
+	}
+	t := f.Type
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && t != timeType
+}
+
+// typeFields returns the json-visible fields of t, walking embedded structs
+// breadth first. When several fields share a name the shallowest one wins;
+// at equal depth a tagged field beats untagged ones, and an unresolved tie
+// hides the name entirely, as in encoding/json.
+func typeFields(t reflect.Type) []structField {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.([]structField)
+	}
+
+	type level struct {
+		typ   reflect.Type
+		index []int
+	}
+	var all []structField
+	visited := map[reflect.Type]bool{}
+	next := []level{{typ: t}}
+	for len(next) > 0 {
+		current := next
+		next = nil
+		for _, l := range current {
+			if visited[l.typ] {
+				continue
+			}
+			visited[l.typ] = true
+
+			for i := 0; i < l.typ.NumField(); i++ {
+				sf := l.typ.Field(i)
+				index := append(append([]int(nil), l.index...), i)
+
+				if isEmbeddedStruct(sf) {
+					ft := sf.Type
+					if ft.Kind() == reflect.Pointer {
+						ft = ft.Elem()
+					}
+					next = append(next, level{typ: ft, index: index})
+					continue
+				}
+				if !sf.IsExported() {
+					continue
+				}
+
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name, opts, _ := strings.Cut(tag, ",")
+				tagged := name != ""
+				if !tagged {
+					name = sf.Name
+				}
+				all = append(all, structField{
+					name:      name,
+					tagged:    tagged,
+					omitEmpty: strings.Contains(opts, "omitempty"),
+					index:     index,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(all, func(i, j int) bool {
+		if all[i].name != all[j].name {
+			return all[i].name < all[j].name
+		}
+		if len(all[i].index) != len(all[j].index) {
+			return len(all[i].index) < len(all[j].index)
+		}
+		return all[i].tagged && !all[j].tagged
+	})
+
+	fields := make([]structField, 0, len(all))
+	for i := 0; i < len(all); {
+		j := i + 1
+		for j < len(all) && all[j].name == all[i].name {
+			j++
+		}
+		group := all[i:j]
+		dominant := group[0]
+		if len(group) == 1 || len(group[1].index) > len(dominant.index) || group[1].tagged != dominant.tagged {
+			fields = append(fields, dominant)
+		}
+		i = j
+	}
+
+	fieldCache.Store(t, fields)
+	return fields
+}
+
+// fieldByIndex walks index from v. Nil embedded pointers are allocated when
+// alloc is set and reported as missing otherwise.
+func fieldByIndex(v reflect.Value, index []int, alloc bool) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !alloc || !v.CanSet() {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+func structToMap(rv reflect.Value) (map[string]any, error) {
+	out := make(map[string]any)
+	for _, f := range typeFields(rv.Type()) {
+		fv, ok := fieldByIndex(rv, f.index, false)
+		if !ok || (f.omitEmpty && fv.IsZero()) {
+			continue
+		}
+		val, err := toMapValue(fv)
+		if err != nil {
+			return nil, fmt.Errorf("util: field %q: %w", f.name, err)
+		}
+		out[f.name] = val
+	}
+	return out, nil
+}
+
+// textMarshaler returns v as an encoding.TextMarshaler, excluding time.Time
+// which is kept as a typed value.
+func textMarshaler(v reflect.Value) (encoding.TextMarshaler, bool) {
+	if v.Type() == timeType {
+		return nil, false
+	}
+	if v.Type().Implements(textMarshalerType) {
+		return v.Interface().(encoding.TextMarshaler), true
+	}
+	if v.CanAddr() && reflect.PointerTo(v.Type()).Implements(textMarshalerType) {
+		return v.Addr().Interface().(encoding.TextMarshaler), true
+	}
+	return nil, false
+}
+
+func toMapValue(v reflect.Value) (any, error) {
+	if v.Kind() == reflect.Pointer && v.IsNil() {
+		return nil, nil
+	}
+	if tm, ok := textMarshaler(v); ok {
+		text, err := tm.MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		return string(text), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		return toMapValue(v.Elem())
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return v.Interface(), nil
+		}
+		return structToMap(v)
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, nil
+		}
+		if !needsConversion(v.Type().Elem()) {
+			return v.Interface(), nil
+		}
+		s := make([]any, v.Len())
+		for i := range s {
+			elem, err := toMapValue(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			s[i] = elem
+		}
+		return s, nil
+	case reflect.Map:
+		if v.IsNil() {
+			return nil, nil
+		}
+		if v.Type().Key().Kind() != reflect.String || !needsConversion(v.Type().Elem()) {
+			return v.Interface(), nil
+		}
+		m := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			elem, err := toMapValue(iter.Value())
+			if err != nil {
+				return nil, err
+			}
+			m[iter.Key().String()] = elem
+		}
+		return m, nil
+	default:
+		return v.Interface(), nil
+	}
+}
+
+// needsConversion reports whether values of type t are rewritten by
+// toMapValue, so containers holding them must be rebuilt.
+func needsConversion(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return false
+	}
+	if t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		return true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return needsConversion(t.Elem())
+	}
+	return false
+}
+
+func mapToStruct(m map[string]any, rv reflect.Value) error {
+	for _, f := range typeFields(rv.Type()) {
+		raw, ok := m[f.name]
+		if !ok {
+			continue
+		}
+		fv, ok := fieldByIndex(rv, f.index, true)
+		if !ok {
+			continue
+		}
+		if err := assign(fv, raw); err != nil {
+			return fmt.Errorf("util: field %q: %w", f.name, err)
+		}
+	}
+	return nil
+}
+
+func assign(dst reflect.Value, raw any) error {
+	if raw == nil {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+
+	src := reflect.ValueOf(raw)
+	if src.Type().AssignableTo(dst.Type()) {
+		dst.Set(src)
+		return nil
+	}
+
+	if s, ok := raw.(string); ok && reflect.PointerTo(dst.Type()).Implements(textUnmarshalerType) {
+		return dst.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	switch dst.Kind() {
+	case reflect.Pointer:
+		elem := reflect.New(dst.Type().Elem())
+		if err := assign(elem.Elem(), raw); err != nil {
+			return err
+		}
+		dst.Set(elem)
+		return nil
+	case reflect.Struct:
+		nested, ok := raw.(map[string]any)
+		if !ok {
+			return fmt.Errorf("cannot convert %T to %s", raw, dst.Type())
+		}
+		return mapToStruct(nested, dst)
+	case reflect.Slice:
+		if src.Kind() != reflect.Slice && src.Kind() != reflect.Array {
+			return fmt.Errorf("cannot convert %T to %s", raw, dst.Type())
+		}
+		s := reflect.MakeSlice(dst.Type(), src.Len(), src.Len())
+		for i := 0; i < src.Len(); i++ {
+			if err := assign(s.Index(i), src.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		dst.Set(s)
+		return nil
+	case reflect.Map:
+		if src.Kind() != reflect.Map {
+			return fmt.Errorf("cannot convert %T to %s", raw, dst.Type())
+		}
+		m := reflect.MakeMapWithSize(dst.Type(), src.Len())
+		iter := src.MapRange()
+		for iter.Next() {
+			key := reflect.New(dst.Type().Key()).Elem()
+			if err := assign(key, iter.Key().Interface()); err != nil {
+				return fmt.Errorf("map key %v: %w", iter.Key(), err)
+			}
+			val := reflect.New(dst.Type().Elem()).Elem()
+			if err := assign(val, iter.Value().Interface()); err != nil {
+				return fmt.Errorf("map key %v: %w", iter.Key(), err)
+			}
+			m.SetMapIndex(key, val)
+		}
+		dst.Set(m)
+		return nil
+	}
+
+	if isNumeric(src.Kind()) && isNumeric(dst.Kind()) {
+		return assignNumeric(dst, src)
+	}
+	if src.Type().ConvertibleTo(dst.Type()) && src.Kind() == dst.Kind() {
+		dst.Set(src.Convert(dst.Type()))
+		return nil
+	}
+	return fmt.Errorf("cannot convert %T to %s", raw, dst.Type())
+}
+
+// assignNumeric converts between numeric kinds, rejecting values that would
+// be truncated, wrap around or lose their sign.
+func assignNumeric(dst, src reflect.Value) error {
+	overflow := func() error {
+		return fmt.Errorf("value %v overflows %s", src.Interface(), dst.Type())
+	}
+
+	switch {
+	case isInt(dst.Kind()):
+		var n int64
+		switch {
+		case isInt(src.Kind()):
+			n = src.Int()
+		case isUint(src.Kind()):
+			if src.Uint() > math.MaxInt64 {
+				return overflow()
+			}
+			n = int64(src.Uint())
+		default:
+			f := src.Float()
+			if f != math.Trunc(f) {
+				return fmt.Errorf("cannot convert non-integral %v to %s", f, dst.Type())
+			}
+			if f < math.MinInt64 || f >= math.MaxInt64 {
+				return overflow()
+			}
+			n = int64(f)
+		}
+		if dst.OverflowInt(n) {
+			return overflow()
+		}
+		dst.SetInt(n)
+	case isUint(dst.Kind()):
+		var n uint64
+		switch {
+		case isInt(src.Kind()):
+			if src.Int() < 0 {
+				return fmt.Errorf("cannot convert negative %v to %s", src.Int(), dst.Type())
+			}
+			n = uint64(src.Int())
+		case isUint(src.Kind()):
+			n = src.Uint()
+		default:
+			f := src.Float()
+			if f != math.Trunc(f) {
+				return fmt.Errorf("cannot convert non-integral %v to %s", f, dst.Type())
+			}
+			if f < 0 {
+				return fmt.Errorf("cannot convert negative %v to %s", f, dst.Type())
+			}
+			if f >= math.MaxUint64 {
+				return overflow()
+			}
+			n = uint64(f)
+		}
+		if dst.OverflowUint(n) {
+			return overflow()
+		}
+		dst.SetUint(n)
+	default:
+		f := src.Convert(reflect.TypeOf(float64(0))).Float()
+		if dst.OverflowFloat(f) {
+			return overflow()
+		}
+		dst.SetFloat(f)
+	}
+	return nil
+}
+
+func isInt(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return false
+}
+
+func isUint(k reflect.Kind) bool {
+	switch k {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+	return false
+}
+
+func isNumeric(k reflect.Kind) bool {
+	return isInt(k) || isUint(k) || k == reflect.Float32 || k == reflect.Float64
+}
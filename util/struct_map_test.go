@@ -0,0 +1,315 @@
+package util
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type address struct {
+	City string `json:"city"`
+	Zip  string `json:"zip,omitempty"`
+}
+
+type Audit struct {
+	CreatedAt time.Time  `json:"created_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+type user struct {
+	Audit
+	ID       int64     `json:"id"`
+	Name     string    `json:"name"`
+	Password string    `json:"-"`
+	Address  address   `json:"address"`
+	Previous []address `json:"previous"`
+	Manager  *address  `json:"manager"`
+}
+
+func TestStructToMapNestedAndEmbedded(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	u := user{
+		Audit:    Audit{CreatedAt: created},
+		ID:       7,
+		Name:     "alice",
+		Password: "secret",
+		Address:  address{City: "Hanoi"},
+		Previous: []address{{City: "Hue", Zip: "53000"}},
+	}
+
+	got, err := StructToMap(&u)
+	if err != nil {
+		t.Fatalf("StructToMap: %v", err)
+	}
+
+	want := map[string]any{
+		"created_at": created,
+		"id":         int64(7),
+		"name":       "alice",
+		"address":    map[string]any{"city": "Hanoi"},
+		"previous":   []any{map[string]any{"city": "Hue", "zip": "53000"}},
+		"manager":    nil,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("StructToMap mismatch\n got: %#v\nwant: %#v", got, want)
+	}
+}
+
+func TestStructToMapRejectsNonStruct(t *testing.T) {
+	if _, err := StructToMap(42); err == nil {
+		t.Fatal("expected error for non-struct input")
+	}
+	var u *user
+	if _, err := StructToMap(u); err == nil {
+		t.Fatal("expected error for nil pointer")
+	}
+}
+
+func TestMapToStructRoundTrip(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	deleted := created.Add(time.Hour)
+	in := user{
+		Audit:    Audit{CreatedAt: created, DeletedAt: &deleted},
+		ID:       7,
+		Name:     "alice",
+		Address:  address{City: "Hanoi", Zip: "10000"},
+		Previous: []address{{City: "Hue"}},
+		Manager:  &address{City: "Da Nang"},
+	}
+
+	m, err := StructToMap(in)
+	if err != nil {
+		t.Fatalf("StructToMap: %v", err)
+	}
+	var out user
+	if err := MapToStruct(m, &out); err != nil {
+		t.Fatalf("MapToStruct: %v", err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Fatalf("round trip mismatch\n got: %#v\nwant: %#v", out, in)
+	}
+}
+
+func TestMapToStructConvertsDecodedJSONValues(t *testing.T) {
+	// Values shaped like the output of json.Unmarshal into map[string]any.
+	m := map[string]any{
+		"created_at": "2024-05-01T12:00:00Z",
+		"id":         float64(9),
+		"name":       "bob",
+		"address":    map[string]any{"city": "Hanoi"},
+		"previous":   []any{map[string]any{"city": "Hue"}},
+		"unknown":    true,
+	}
+
+	var out user
+	if err := MapToStruct(m, &out); err != nil {
+		t.Fatalf("MapToStruct: %v", err)
+	}
+
+	if want := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC); !out.CreatedAt.Equal(want) {
+		t.Errorf("CreatedAt = %v, want %v", out.CreatedAt, want)
+	}
+	if out.ID != 9 || out.Name != "bob" || out.Address.City != "Hanoi" {
+		t.Errorf("unexpected scalar fields: %+v", out)
+	}
+	if len(out.Previous) != 1 || out.Previous[0].City != "Hue" {
+		t.Errorf("Previous = %+v", out.Previous)
+	}
+}
+
+func TestMapToStructTypeMismatch(t *testing.T) {
+	var out user
+	if err := MapToStruct(map[string]any{"name": 12}, &out); err == nil {
+		t.Fatal("expected error assigning int to string field")
+	}
+	if err := MapToStruct(map[string]any{"id": 1.5}, &out); err == nil {
+		t.Fatal("expected error assigning non-integral float to int field")
+	}
+	if err := MapToStruct(map[string]any{}, out); err == nil {
+		t.Fatal("expected error for non-pointer target")
+	}
+}
+
+func TestMapToStructNumericRange(t *testing.T) {
+	type numbers struct {
+		I8  int8    `json:"i8"`
+		U   uint    `json:"u"`
+		U16 uint16  `json:"u16"`
+		I64 int64   `json:"i64"`
+		F32 float32 `json:"f32"`
+	}
+
+	tests := []struct {
+		name    string
+		in      map[string]any
+		wantErr bool
+	}{
+		{name: "int8 in range", in: map[string]any{"i8": -128.0}},
+		{name: "int8 overflow", in: map[string]any{"i8": 300.0}, wantErr: true},
+		{name: "int8 overflow from int", in: map[string]any{"i8": 128}, wantErr: true},
+		{name: "uint from negative float", in: map[string]any{"u": -1.0}, wantErr: true},
+		{name: "uint from negative int", in: map[string]any{"u": -1}, wantErr: true},
+		{name: "uint16 overflow", in: map[string]any{"u16": uint64(70000)}, wantErr: true},
+		{name: "int64 from huge uint", in: map[string]any{"i64": uint64(math.MaxUint64)}, wantErr: true},
+		{name: "int64 from huge float", in: map[string]any{"i64": 1e19}, wantErr: true},
+		{name: "float32 overflow", in: map[string]any{"f32": 1e39}, wantErr: true},
+		{name: "float32 in range", in: map[string]any{"f32": 1.5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out numbers
+			err := MapToStruct(tt.in, &out)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("MapToStruct(%v) error = %v, wantErr %v (out %+v)", tt.in, err, tt.wantErr, out)
+			}
+		})
+	}
+}
+
+type innerNamed struct {
+	Name  string `json:"name"`
+	Inner string `json:"inner"`
+}
+
+type tieA struct {
+	Dup string
+}
+
+type tieB struct {
+	Dup string
+}
+
+type outerNamed struct {
+	innerNamed
+	tieA
+	tieB
+	Name string `json:"name"`
+}
+
+func TestStructFieldDominance(t *testing.T) {
+	in := outerNamed{
+		innerNamed: innerNamed{Name: "inner", Inner: "kept"},
+		tieA:       tieA{Dup: "a"},
+		tieB:       tieB{Dup: "b"},
+		Name:       "outer",
+	}
+
+	got, err := StructToMap(in)
+	if err != nil {
+		t.Fatalf("StructToMap: %v", err)
+	}
+	want := map[string]any{"name": "outer", "inner": "kept"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("StructToMap = %#v, want %#v", got, want)
+	}
+
+	var out outerNamed
+	if err := MapToStruct(map[string]any{"name": "x", "inner": "y", "Dup": "z"}, &out); err != nil {
+		t.Fatalf("MapToStruct: %v", err)
+	}
+	if out.Name != "x" || out.innerNamed.Name != "" || out.Inner != "y" {
+		t.Fatalf("MapToStruct dominance: %+v", out)
+	}
+	if out.tieA.Dup != "" || out.tieB.Dup != "" {
+		t.Fatalf("ambiguous field should be ignored: %+v", out)
+	}
+}
+
+type withMaps struct {
+	Labels    map[string]string  `json:"labels"`
+	Addresses map[string]address `json:"addresses"`
+	Counts    map[string]int     `json:"counts"`
+}
+
+func TestStructMapFields(t *testing.T) {
+	in := withMaps{
+		Labels:    map[string]string{"env": "prod"},
+		Addresses: map[string]address{"home": {City: "Hanoi"}},
+		Counts:    map[string]int{"a": 1},
+	}
+
+	m, err := StructToMap(in)
+	if err != nil {
+		t.Fatalf("StructToMap: %v", err)
+	}
+	if got, want := m["addresses"], map[string]any{"home": map[string]any{"city": "Hanoi"}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("addresses = %#v, want %#v", got, want)
+	}
+
+	var out withMaps
+	if err := MapToStruct(m, &out); err != nil {
+		t.Fatalf("MapToStruct round trip: %v", err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Fatalf("round trip mismatch\n got: %#v\nwant: %#v", out, in)
+	}
+
+	decoded := map[string]any{
+		"labels":    map[string]any{"env": "dev"},
+		"addresses": map[string]any{"work": map[string]any{"city": "Hue"}},
+		"counts":    map[string]any{"b": float64(2)},
+	}
+	out = withMaps{}
+	if err := MapToStruct(decoded, &out); err != nil {
+		t.Fatalf("MapToStruct decoded: %v", err)
+	}
+	if out.Labels["env"] != "dev" || out.Addresses["work"].City != "Hue" || out.Counts["b"] != 2 {
+		t.Fatalf("decoded maps: %+v", out)
+	}
+}
+
+// money stands in for decimal types, which round-trip through their text form.
+type money struct {
+	cents int64
+}
+
+func (m money) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%d.%02d", m.cents/100, m.cents%100)), nil
+}
+
+func (m *money) UnmarshalText(text []byte) error {
+	var whole, frac int64
+	if _, err := fmt.Sscanf(string(text), "%d.%d", &whole, &frac); err != nil {
+		return err
+	}
+	m.cents = whole*100 + frac
+	return nil
+}
+
+type invoice struct {
+	Total    money   `json:"total"`
+	Discount *money  `json:"discount"`
+	Lines    []money `json:"lines"`
+}
+
+func TestStructMapTextMarshaler(t *testing.T) {
+	in := invoice{
+		Total:    money{cents: 1234},
+		Discount: &money{cents: 50},
+		Lines:    []money{{cents: 1000}, {cents: 234}},
+	}
+
+	m, err := StructToMap(in)
+	if err != nil {
+		t.Fatalf("StructToMap: %v", err)
+	}
+	want := map[string]any{"total": "12.34", "discount": "0.50", "lines": []any{"10.00", "2.34"}}
+	if !reflect.DeepEqual(m, want) {
+		t.Fatalf("StructToMap = %#v, want %#v", m, want)
+	}
+
+	var out invoice
+	if err := MapToStruct(m, &out); err != nil {
+		t.Fatalf("MapToStruct: %v", err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Fatalf("round trip mismatch\n got: %#v\nwant: %#v", out, in)
+	}
+
+	if err := MapToStruct(map[string]any{"total": "abc"}, &out); err == nil {
+		t.Fatal("expected UnmarshalText error to be returned")
+	}
+}